@@ -0,0 +1,76 @@
+package logctx
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type addedAccumulator struct {
+	mu    sync.Mutex
+	meta  Meta
+	outer *addedAccumulator
+}
+
+// TrackAdded creates a new context which records all metadata added to it, or
+// to any context derived from it, with `WithMeta`. Install it in middleware at
+// the start of a request and log it in the summary entry with `Added` once the
+// request has been handled, to reveal which code paths enriched the request:
+//
+//	func Middleware(next http.Handler) http.Handler {
+//	    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//	        ctx := logctx.TrackAdded(r.Context())
+//	        next.ServeHTTP(w, r.WithContext(ctx))
+//	        logger.Info("request finished", logctx.Zap(ctx, logctx.Added(ctx))...)
+//	    })
+//	}
+//
+// Since `WithMeta` never changes its parent, the middleware's own context
+// doesn't see the keys its handler adds. This accumulator does. It's safe for
+// handlers to add metadata from multiple goroutines.
+//
+// Tracking can be nested, in which case metadata is recorded by every
+// accumulator it's beneath.
+func TrackAdded(ctx context.Context) context.Context {
+	existing, _ := ctx.Value(metaKey{}).(metadata)
+	existing.added = &addedAccumulator{meta: Meta{}, outer: existing.added}
+
+	return context.WithValue(ctx, metaKey{}, existing)
+}
+
+// Added returns an "added_context" log field containing any metadata that was
+// added, or changed, beneath the accumulator installed by `TrackAdded`, with
+// the latest value for each key. If nothing was added, or the context isn't
+// tracked, the field is skipped.
+func Added(ctx context.Context) zapcore.Field {
+	value, _ := ctx.Value(metaKey{}).(metadata)
+	if value.added == nil {
+		return zap.Skip()
+	}
+
+	value.added.mu.Lock()
+	added := copyMeta(value.added.meta)
+	value.added.mu.Unlock()
+
+	if len(added) == 0 {
+		return zap.Skip()
+	}
+
+	return zap.Object("added_context", added)
+}
+
+// record stores every key in data whose value differs from the one in the
+// previous metadata, using the final values from merged.
+func (a *addedAccumulator) record(previous, merged, data Meta) {
+	for ; a != nil; a = a.outer {
+		a.mu.Lock()
+		for k := range data {
+			if old, ok := previous[k]; !ok || old != merged[k] {
+				a.meta[k] = merged[k]
+			}
+		}
+		a.mu.Unlock()
+	}
+}
@@ -0,0 +1,109 @@
+package logctx_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/logctx"
+)
+
+func TestAddedMiddleware(t *testing.T) {
+	a := assert.New(t)
+	logger, buf := testLogger()
+
+	middleware := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := logctx.WithMeta(r.Context(), logctx.Meta{"request_id": "abc", "deal_id": "xyz"})
+			ctx = logctx.TrackAdded(ctx)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+
+			logger.Info("request finished", logctx.Zap(ctx, logctx.Added(ctx))...)
+		})
+	}
+
+	// the handler never hands its context back to the middleware
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := logctx.WithMeta(r.Context(), logctx.Meta{"user_id": "southclaws"})
+		ctx = logctx.WithMeta(ctx, logctx.Meta{"deal_id": "changed", "request_id": "abc"})
+		logctx.Snapshot(ctx)
+	})
+
+	middleware(handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	a.Contains(buf.String(), `"context":{`)
+	a.Contains(buf.String(), `"added_context":{`)
+
+	added := addedContext(t, buf.Bytes())
+	a.Equal(map[string]string{"user_id": "southclaws", "deal_id": "changed"}, added)
+}
+
+func TestAddedConcurrent(t *testing.T) {
+	a := assert.New(t)
+	logger, buf := testLogger()
+
+	ctx := logctx.TrackAdded(context.Background())
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			logctx.WithMeta(ctx, logctx.Meta{key: "set"})
+		}(key)
+	}
+	wg.Wait()
+
+	logger.Info("request finished", logctx.Added(ctx))
+
+	a.Equal(map[string]string{"a": "set", "b": "set", "c": "set", "d": "set"}, addedContext(t, buf.Bytes()))
+}
+
+func TestAddedNested(t *testing.T) {
+	a := assert.New(t)
+
+	outer := logctx.TrackAdded(context.Background())
+	inner := logctx.TrackAdded(logctx.WithMeta(outer, logctx.Meta{"user_id": "southclaws"}))
+
+	logctx.WithMeta(inner, logctx.Meta{"deal_id": "xyz"})
+
+	// the outer accumulator sees everything, the inner only what came after it
+	logger, buf := testLogger()
+	logger.Info("outer", logctx.Added(outer))
+	a.Equal(map[string]string{"user_id": "southclaws", "deal_id": "xyz"}, addedContext(t, buf.Bytes()))
+
+	logger, buf = testLogger()
+	logger.Info("inner", logctx.Added(inner))
+	a.Equal(map[string]string{"deal_id": "xyz"}, addedContext(t, buf.Bytes()))
+}
+
+func TestAddedNothing(t *testing.T) {
+	a := assert.New(t)
+	logger, buf := testLogger()
+
+	tracked := logctx.TrackAdded(context.Background())
+
+	logger.Info("request finished", logctx.Added(tracked), logctx.Added(context.Background()), zap.String("k", "v"))
+
+	a.NotContains(buf.String(), `"added_context"`)
+
+	// tracking alone doesn't add an empty context field
+	a.Empty(logctx.Zap(tracked))
+}
+
+func addedContext(t *testing.T, entry []byte) map[string]string {
+	var decoded struct {
+		Added map[string]string `json:"added_context"`
+	}
+	if err := json.Unmarshal(entry, &decoded); err != nil {
+		t.Fatalf("invalid log entry %q: %v", entry, err)
+	}
+	return decoded.Added
+}
@@ -0,0 +1,187 @@
+package benchmarks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/Southclaws/logctx"
+)
+
+// scenario describes the shape of the metadata a request has accumulated by
+// the time something is logged. Each layer is one call to WithMeta (or With)
+// and each layer adds keys-per-layer keys.
+type scenario struct {
+	name   string
+	layers int
+	keys   int
+}
+
+var scenarios = []scenario{
+	{"no_meta", 0, 0},
+	{"5_keys", 1, 5},
+	{"50_keys", 1, 50},
+	{"deep_chain", 20, 1},
+}
+
+func discardLogger() *zap.Logger {
+	return zap.New(zapcore.NewCore(
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		zapcore.AddSync(io.Discard),
+		zap.DebugLevel,
+	))
+}
+
+// pairs holds the keys and values for each layer of a scenario, formatted once
+// up front so the benchmarks only measure building the metadata from them.
+type pairs [][][2]string
+
+func (s scenario) pairs() pairs {
+	p := make(pairs, s.layers)
+	for l := range p {
+		p[l] = make([][2]string, s.keys)
+		for k := range p[l] {
+			p[l][k] = [2]string{fmt.Sprintf("key_%d_%d", l, k), fmt.Sprintf("value_%d_%d", l, k)}
+		}
+	}
+	return p
+}
+
+func (p pairs) meta(layer int) logctx.Meta {
+	m := make(logctx.Meta, len(p[layer]))
+	for _, kv := range p[layer] {
+		m[kv[0]] = kv[1]
+	}
+	return m
+}
+
+func (p pairs) fields(layer int) []zap.Field {
+	fields := make([]zap.Field, 0, len(p[layer]))
+	for _, kv := range p[layer] {
+		fields = append(fields, zap.String(kv[0], kv[1]))
+	}
+	return fields
+}
+
+// ctxzap-style: the logger itself is stored in the context and each layer
+// replaces it with a child logger.
+
+type loggerKey struct{}
+
+func withLogger(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+func extractLogger(ctx context.Context) *zap.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*zap.Logger); ok {
+		return l
+	}
+	return zap.NewNop()
+}
+
+// BenchmarkLog measures the cost of a single log call once the metadata has
+// already been attached, which is the common case: decorate once per request,
+// log many times.
+func BenchmarkLog(b *testing.B) {
+	for _, s := range scenarios {
+		s := s
+		p := s.pairs()
+
+		b.Run(s.name+"/logctx", func(b *testing.B) {
+			logger := discardLogger()
+			ctx := context.Background()
+			for l := 0; l < s.layers; l++ {
+				ctx = logctx.WithMeta(ctx, p.meta(l))
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				logger.Info("benchmark", logctx.Zap(ctx, zap.Int("i", i))...)
+			}
+		})
+
+		b.Run(s.name+"/logger_with", func(b *testing.B) {
+			logger := discardLogger()
+			for l := 0; l < s.layers; l++ {
+				logger = logger.With(p.fields(l)...)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				logger.Info("benchmark", zap.Int("i", i))
+			}
+		})
+
+		b.Run(s.name+"/ctxzap_style", func(b *testing.B) {
+			ctx := withLogger(context.Background(), discardLogger())
+			for l := 0; l < s.layers; l++ {
+				ctx = withLogger(ctx, extractLogger(ctx).With(p.fields(l)...))
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				extractLogger(ctx).Info("benchmark", zap.Int("i", i))
+			}
+		})
+	}
+}
+
+// BenchmarkDecorate measures the cost of building up the metadata for a
+// request from scratch and then logging once, which is the worst case. Every
+// variant builds its metadata or fields afresh on each iteration, the same way
+// a real request would.
+func BenchmarkDecorate(b *testing.B) {
+	for _, s := range scenarios {
+		s := s
+		p := s.pairs()
+
+		b.Run(s.name+"/logctx", func(b *testing.B) {
+			logger := discardLogger()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ctx := context.Background()
+				for l := 0; l < s.layers; l++ {
+					ctx = logctx.WithMeta(ctx, p.meta(l))
+				}
+				logger.Info("benchmark", logctx.Zap(ctx)...)
+			}
+		})
+
+		b.Run(s.name+"/logger_with", func(b *testing.B) {
+			root := discardLogger()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				logger := root
+				for l := 0; l < s.layers; l++ {
+					logger = logger.With(p.fields(l)...)
+				}
+				logger.Info("benchmark")
+			}
+		})
+
+		b.Run(s.name+"/ctxzap_style", func(b *testing.B) {
+			root := withLogger(context.Background(), discardLogger())
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ctx := root
+				for l := 0; l < s.layers; l++ {
+					ctx = withLogger(ctx, extractLogger(ctx).With(p.fields(l)...))
+				}
+				extractLogger(ctx).Info("benchmark")
+			}
+		})
+	}
+}
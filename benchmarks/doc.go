@@ -0,0 +1,7 @@
+// Package benchmarks compares the cost of logging with logctx against other
+// common ways of carrying structured log fields through a call tree.
+//
+// There's no code here, only benchmarks. Run them with:
+//
+//	go test -bench . -benchmem ./benchmarks
+package benchmarks
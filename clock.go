@@ -0,0 +1,39 @@
+package logctx
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock tells the time. It's used wherever the package needs to know the
+// current time, such as timestamping events recorded with `Emit`.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// clockHolder wraps the Clock so atomic.Value always sees the same type.
+type clockHolder struct{ Clock }
+
+var clock atomic.Value
+
+func init() {
+	clock.Store(clockHolder{systemClock{}})
+}
+
+// SetClock replaces the clock used by the package, which makes time-dependent
+// behaviour deterministic in tests. See logctxtest.Clock for a fake. Passing
+// nil restores the system clock.
+func SetClock(c Clock) {
+	if c == nil {
+		c = systemClock{}
+	}
+	clock.Store(clockHolder{c})
+}
+
+func now() time.Time {
+	return clock.Load().(clockHolder).Now()
+}
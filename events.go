@@ -0,0 +1,103 @@
+package logctx
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type eventsKey struct{}
+
+// Event is a named, timestamped occurrence recorded with `Emit`.
+type Event struct {
+	Name  string
+	Time  time.Time
+	Attrs Meta
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler
+func (e Event) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", e.Name)
+	enc.AddTime("time", e.Time)
+	if len(e.Attrs) > 0 {
+		return enc.AddObject("attrs", e.Attrs)
+	}
+	return nil
+}
+
+type events []Event
+
+// MarshalLogArray implements zapcore.ArrayMarshaler
+func (es events) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, e := range es {
+		if err := enc.AppendObject(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type accumulator struct {
+	mu     sync.Mutex
+	events events
+}
+
+// WithEvents creates a new context which accumulates events recorded with
+// `Emit`. Call it once at the start of a request (or job, or any other unit of
+// work) and then log the events out in a summary entry with `Events`.
+func WithEvents(ctx context.Context) context.Context {
+	return context.WithValue(ctx, eventsKey{}, &accumulator{})
+}
+
+// Emit records a named event, along with the current time and any attributes,
+// in the accumulator started by `WithEvents`. This works like a lightweight,
+// in-process span log:
+//
+//	logctx.Emit(ctx, "cache_miss", logctx.Meta{"key": key})
+//
+// The attributes are copied, so the map can be reused afterwards. It's safe to
+// call from multiple goroutines. If the context has no accumulator, the event
+// is discarded.
+func Emit(ctx context.Context, name string, attrs Meta) {
+	acc, ok := ctx.Value(eventsKey{}).(*accumulator)
+	if !ok {
+		return
+	}
+
+	// Copy the attributes so the caller can reuse their map, such as across
+	// loop iterations, without rewriting events already recorded.
+	e := Event{Name: name, Time: now()}
+	if len(attrs) > 0 {
+		e.Attrs = copyMeta(attrs)
+	}
+
+	acc.mu.Lock()
+	acc.events = append(acc.events, e)
+	acc.mu.Unlock()
+}
+
+// Events returns an "events" log field containing every event recorded with
+// `Emit` so far, in the order they were emitted. If there are no events, or the
+// context has no accumulator, the field is skipped.
+//
+//	s.l.Info("request finished", logctx.Zap(ctx, logctx.Events(ctx))...)
+func Events(ctx context.Context) zapcore.Field {
+	acc, ok := ctx.Value(eventsKey{}).(*accumulator)
+	if !ok {
+		return zap.Skip()
+	}
+
+	acc.mu.Lock()
+	recorded := make(events, len(acc.events))
+	copy(recorded, acc.events)
+	acc.mu.Unlock()
+
+	if len(recorded) == 0 {
+		return zap.Skip()
+	}
+
+	return zap.Array("events", recorded)
+}
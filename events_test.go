@@ -0,0 +1,92 @@
+package logctx_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/logctx"
+	"github.com/Southclaws/logctx/logctxtest"
+)
+
+func TestEvents(t *testing.T) {
+	a := assert.New(t)
+	logger, buf := testLogger()
+
+	ctx := logctx.WithEvents(context.Background())
+
+	logctx.Emit(ctx, "cache_miss", logctx.Meta{"key": "user:1"})
+	logctx.Emit(ctx, "db_query", nil)
+
+	logger.Info("request finished", logctx.Events(ctx))
+
+	var entry struct {
+		Events []struct {
+			Name  string            `json:"name"`
+			Time  float64           `json:"time"`
+			Attrs map[string]string `json:"attrs"`
+		} `json:"events"`
+	}
+	a.NoError(json.Unmarshal(buf.Bytes(), &entry))
+
+	a.Len(entry.Events, 2)
+	a.Equal("cache_miss", entry.Events[0].Name)
+	a.Equal(map[string]string{"key": "user:1"}, entry.Events[0].Attrs)
+	a.NotZero(entry.Events[0].Time)
+	a.Equal("db_query", entry.Events[1].Name)
+	a.Nil(entry.Events[1].Attrs)
+}
+
+func TestEventsEmpty(t *testing.T) {
+	a := assert.New(t)
+	logger, buf := testLogger()
+
+	// without an accumulator, events are discarded
+	root := context.Background()
+	logctx.Emit(root, "discarded", nil)
+
+	logger.Info("request finished", logctx.Events(root), logctx.Events(logctx.WithEvents(root)))
+
+	a.NotContains(buf.String(), `"events"`)
+}
+
+func TestEventsClock(t *testing.T) {
+	a := assert.New(t)
+	logger, buf := testLogger()
+
+	clock := logctxtest.NewClock(time.Unix(1000, 0))
+	logctx.SetClock(clock)
+	defer logctx.SetClock(nil)
+
+	ctx := logctx.WithEvents(context.Background())
+
+	logctx.Emit(ctx, "first", nil)
+	clock.Advance(1500 * time.Millisecond)
+	logctx.Emit(ctx, "second", nil)
+
+	logger.Info("request finished", logctx.Events(ctx))
+
+	a.Contains(buf.String(), `"events":[{"name":"first","time":1000},{"name":"second","time":1001.5}]`)
+}
+
+func TestEventsAttrsCopied(t *testing.T) {
+	a := assert.New(t)
+	logger, buf := testLogger()
+
+	ctx := logctx.WithEvents(context.Background())
+
+	// reusing the same map must not rewrite earlier events
+	attrs := logctx.Meta{}
+	for _, id := range []string{"1", "2"} {
+		attrs["item_id"] = id
+		logctx.Emit(ctx, "item", attrs)
+	}
+
+	logger.Info("request finished", logctx.Events(ctx))
+
+	a.Contains(buf.String(), `"attrs":{"item_id":"1"}`)
+	a.Contains(buf.String(), `"attrs":{"item_id":"2"}`)
+}
@@ -0,0 +1,46 @@
+package logctx
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type frozenKey struct{}
+
+// frozenHook holds the func(context.Context, Meta) set by SetFrozenHook.
+var frozenHook atomic.Value
+
+// Freeze returns a context whose metadata can no longer be changed. Any calls to
+// `WithMeta` on the returned context, or on contexts derived from it, are
+// rejected and return the context unmodified. If a hook has been set with
+// `SetFrozenHook` it is called with the rejected metadata.
+//
+// This is useful at trust boundaries, such as after authentication middleware
+// has set identity fields, where handler code must not be able to alter them.
+func Freeze(ctx context.Context) context.Context {
+	if IsFrozen(ctx) {
+		return ctx
+	}
+
+	return context.WithValue(ctx, frozenKey{}, true)
+}
+
+// IsFrozen reports whether the given context was frozen with `Freeze`.
+func IsFrozen(ctx context.Context) bool {
+	frozen, _ := ctx.Value(frozenKey{}).(bool)
+	return frozen
+}
+
+// SetFrozenHook sets a function which is called whenever `WithMeta` rejects
+// metadata because the context was frozen. This can be used to log or count
+// attempts to alter metadata past a trust boundary. Passing nil removes the
+// hook.
+func SetFrozenHook(hook func(ctx context.Context, rejected Meta)) {
+	frozenHook.Store(hook)
+}
+
+func rejectFrozen(ctx context.Context, data Meta) {
+	if hook, _ := frozenHook.Load().(func(context.Context, Meta)); hook != nil {
+		hook(ctx, data)
+	}
+}
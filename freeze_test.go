@@ -0,0 +1,72 @@
+package logctx_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/logctx"
+)
+
+func TestFreeze(t *testing.T) {
+	a := assert.New(t)
+	logger, buf := testLogger()
+
+	ctx := logctx.WithMeta(context.Background(), map[string]string{"user_id": "southclaws"})
+	frozen := logctx.Freeze(ctx)
+
+	a.False(logctx.IsFrozen(ctx))
+	a.True(logctx.IsFrozen(frozen))
+
+	// both overwriting and adding keys are rejected
+	child := logctx.WithMeta(frozen, map[string]string{"user_id": "impostor", "deal_id": "xyz"})
+	a.Equal(frozen, child)
+
+	logger.Info("test context", logctx.Zap(child)...)
+
+	a.Contains(buf.String(), `"context":{"user_id":"southclaws"}`)
+}
+
+func TestFreezeParent(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := logctx.WithMeta(context.Background(), map[string]string{"user_id": "southclaws"})
+	frozen := logctx.Freeze(ctx)
+
+	// the unfrozen parent can still be decorated, without affecting the frozen one
+	changed := logctx.WithMeta(ctx, map[string]string{"user_id": "impostor"})
+
+	a.Equal(logctx.Meta{"user_id": "impostor"}, logctx.Snapshot(changed).Copy())
+	a.Equal(logctx.Meta{"user_id": "southclaws"}, logctx.Snapshot(frozen).Copy())
+}
+
+func TestFreezeHook(t *testing.T) {
+	a := assert.New(t)
+
+	var rejected logctx.Meta
+	logctx.SetFrozenHook(func(ctx context.Context, data logctx.Meta) {
+		rejected = data
+	})
+	defer logctx.SetFrozenHook(nil)
+
+	frozen := logctx.Freeze(context.Background())
+	logctx.WithMeta(frozen, map[string]string{"user_id": "impostor"})
+
+	a.Equal(logctx.Meta{"user_id": "impostor"}, rejected)
+	a.Zero(logctx.Snapshot(frozen).Len())
+}
+
+func TestFreezeForeignValue(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := logctx.WithMeta(context.Background(), map[string]string{"user_id": "southclaws"})
+	frozen := logctx.Freeze(ctx)
+
+	// the metadata key is unexported, so other packages can't replace it
+	forged := context.WithValue(frozen, struct{}{}, logctx.Meta{"user_id": "impostor"})
+
+	a.True(logctx.IsFrozen(forged))
+	v, _ := logctx.Snapshot(forged).Get("user_id")
+	a.Equal("southclaws", v)
+}
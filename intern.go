@@ -0,0 +1,110 @@
+package logctx
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// interning holds the active *interner, or a nil *interner when disabled.
+var interning atomic.Value
+
+func init() {
+	interning.Store((*interner)(nil))
+}
+
+// EnableInterning turns on string interning for the values of the given keys
+// in metadata passed to `WithMeta`. Values which are repeated across many
+// contexts, such as endpoint names or tenant IDs, will then share a single copy
+// in memory instead of one per request. This is only worth it for services
+// which hold many thousands of decorated contexts at once and build their
+// metadata strings dynamically.
+//
+//	logctx.EnableInterning(10000, "endpoint", "tenant_id")
+//
+// Only list keys whose values are frequently repeated. Values of any other key,
+// such as request IDs, are stored as-is so they can't crowd the table out.
+//
+// At most limit distinct strings are held, once the table is full any new
+// strings are stored as-is. Calling EnableInterning again replaces the table
+// with a new, empty one.
+func EnableInterning(limit int, keys ...string) {
+	allowed := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		allowed[k] = struct{}{}
+	}
+
+	interning.Store(&interner{
+		limit: limit,
+		keys:  allowed,
+		table: make(map[string]string),
+	})
+}
+
+// DisableInterning turns off string interning and releases the table.
+func DisableInterning() {
+	interning.Store((*interner)(nil))
+}
+
+// InternedStrings returns how many strings are held in the interning table, or
+// zero if interning is disabled. It's the only state the package keeps outside
+// of contexts, so it can be checked after a burst of requests to make sure the
+// table isn't growing without bound.
+func InternedStrings() int {
+	i := interning.Load().(*interner)
+	if i == nil {
+		return 0
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.table)
+}
+
+type interner struct {
+	mu    sync.RWMutex
+	limit int
+	keys  map[string]struct{}
+	table map[string]string
+}
+
+func (i *interner) intern(s string) string {
+	i.mu.RLock()
+	interned, ok := i.table[s]
+	i.mu.RUnlock()
+	if ok {
+		return interned
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if interned, ok := i.table[s]; ok {
+		return interned
+	}
+	if len(i.table) >= i.limit {
+		return s
+	}
+
+	i.table[s] = s
+	return s
+}
+
+// mergeInterned copies all of the keys and values from src into dst, interning
+// allowed keys and their values first if interning is enabled.
+func mergeInterned(dst, src Meta) {
+	i := interning.Load().(*interner)
+	if i == nil {
+		for k, v := range src {
+			dst[k] = v
+		}
+		return
+	}
+
+	for k, v := range src {
+		if _, ok := i.keys[k]; ok {
+			dst[i.intern(k)] = i.intern(v)
+		} else {
+			dst[k] = v
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package logctx_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/Southclaws/logctx"
+)
+
+func stringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
+
+func encodedValue(ctx context.Context, key string) string {
+	enc := zapcore.NewMapObjectEncoder()
+	logctx.EncodeTo(ctx, enc)
+	return enc.Fields[key].(string)
+}
+
+func TestInterning(t *testing.T) {
+	a := assert.New(t)
+
+	logctx.EnableInterning(10, "tenant_id")
+	defer logctx.DisableInterning()
+
+	// build the same value twice so they are backed by different memory
+	v1 := strings.Repeat("tenant", 2)
+	v2 := strings.Repeat("tenant", 2)
+	a.NotEqual(stringData(v1), stringData(v2))
+
+	ctx1 := logctx.WithMeta(context.Background(), logctx.Meta{"tenant_id": v1})
+	ctx2 := logctx.WithMeta(context.Background(), logctx.Meta{"tenant_id": v2})
+
+	a.Equal("tenanttenant", encodedValue(ctx2, "tenant_id"))
+	a.Equal(stringData(encodedValue(ctx1, "tenant_id")), stringData(encodedValue(ctx2, "tenant_id")))
+}
+
+func TestInterningLimit(t *testing.T) {
+	a := assert.New(t)
+
+	// only enough room for the key and the first value
+	logctx.EnableInterning(2, "request_id")
+	defer logctx.DisableInterning()
+
+	logctx.WithMeta(context.Background(), logctx.Meta{"request_id": "first"})
+
+	v1 := strings.Repeat("second", 2)
+	v2 := strings.Repeat("second", 2)
+
+	ctx1 := logctx.WithMeta(context.Background(), logctx.Meta{"request_id": v1})
+	ctx2 := logctx.WithMeta(context.Background(), logctx.Meta{"request_id": v2})
+
+	// the table is full, so the values are left alone
+	a.Equal("secondsecond", encodedValue(ctx2, "request_id"))
+	a.NotEqual(stringData(encodedValue(ctx1, "request_id")), stringData(encodedValue(ctx2, "request_id")))
+}
+
+func TestInterningSaturation(t *testing.T) {
+	a := assert.New(t)
+
+	logctx.EnableInterning(100, "tenant_id")
+	defer logctx.DisableInterning()
+
+	// more distinct request IDs than the table can hold, which are not interned
+	for i := 0; i < 200; i++ {
+		logctx.WithMeta(context.Background(), logctx.Meta{"request_id": fmt.Sprint(i)})
+	}
+
+	v1 := strings.Repeat("tenant", 2)
+	v2 := strings.Repeat("tenant", 2)
+
+	ctx1 := logctx.WithMeta(context.Background(), logctx.Meta{"tenant_id": v1, "request_id": "a"})
+	ctx2 := logctx.WithMeta(context.Background(), logctx.Meta{"tenant_id": v2, "request_id": "b"})
+
+	// the repeated tenant is still interned
+	a.Equal(stringData(encodedValue(ctx1, "tenant_id")), stringData(encodedValue(ctx2, "tenant_id")))
+}
+
+func TestInterningOtherKeys(t *testing.T) {
+	a := assert.New(t)
+
+	logctx.EnableInterning(100, "tenant_id")
+	defer logctx.DisableInterning()
+
+	v1 := strings.Repeat("endpoint", 2)
+	v2 := strings.Repeat("endpoint", 2)
+
+	ctx1 := logctx.WithMeta(context.Background(), logctx.Meta{"endpoint": v1})
+	ctx2 := logctx.WithMeta(context.Background(), logctx.Meta{"endpoint": v2})
+
+	// keys that weren't listed are stored as-is
+	a.NotEqual(stringData(encodedValue(ctx1, "endpoint")), stringData(encodedValue(ctx2, "endpoint")))
+}
+
+func TestInternedStrings(t *testing.T) {
+	a := assert.New(t)
+
+	a.Zero(logctx.InternedStrings())
+
+	logctx.EnableInterning(3, "tenant_id")
+
+	for i := 0; i < 10; i++ {
+		logctx.WithMeta(context.Background(), logctx.Meta{"tenant_id": fmt.Sprint(i), "request_id": fmt.Sprint(i)})
+	}
+
+	// the key and values up to the limit, and nothing for request IDs
+	a.Equal(3, logctx.InternedStrings())
+
+	logctx.DisableInterning()
+	a.Zero(logctx.InternedStrings())
+}
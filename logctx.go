@@ -9,7 +9,19 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-var contextKey = struct{}{}
+type metaKey struct{}
+
+// metadata is the value stored in a context by WithMeta.
+type metadata struct {
+	meta Meta
+
+	// calls is the number of WithMeta calls in the chain leading to this value,
+	// only counted while a loop hook is set.
+	calls int
+
+	// added is the accumulator installed by TrackAdded, if any.
+	added *addedAccumulator
+}
 
 // Meta is a simple wrapper around a basic hash table that can be serialised
 // into a log field for zap.
@@ -47,17 +59,43 @@ func (m Meta) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 //
 // Then, when you need to log it out, use `logctx.Zap`.
 //
+// The metadata in the given context is never modified, so it's safe to decorate
+// a shared context, such as one set up when the process starts, from many
+// goroutines at once. The data map is copied, so it can be reused afterwards.
+//
+// This means a parent context doesn't see keys added to its children. Earlier
+// versions merged them into a map shared with the parent, which raced when the
+// parent was shared. To log what a handler added from middleware, use
+// `TrackAdded`.
+//
+// If the context was frozen with `Freeze`, the metadata is rejected and the
+// context is returned unmodified.
+//
 func WithMeta(ctx context.Context, data Meta) context.Context {
-	// We don't need to stack metadata, just update/overwrite any existing keys.
-	if existing, ok := ctx.Value(contextKey).(Meta); existing != nil && ok {
-		for k, v := range data {
-			existing[k] = v
-		}
+	if IsFrozen(ctx) {
+		rejectFrozen(ctx, data)
+		return ctx
+	}
 
-		return context.WithValue(ctx, contextKey, existing)
+	existing, _ := ctx.Value(metaKey{}).(metadata)
+
+	// Contexts are shared freely between goroutines, so the existing metadata
+	// is never modified. Instead, copy it and overwrite any existing keys.
+	merged := make(Meta, len(existing.meta)+len(data))
+	for k, v := range existing.meta {
+		merged[k] = v
 	}
+	mergeInterned(merged, data)
+
+	existing.added.record(existing.meta, merged, data)
+
+	loop := loopDetector.Load().(*loopHook)
+	calls := loop.count(existing.calls)
+
+	ctx = context.WithValue(ctx, metaKey{}, metadata{meta: merged, calls: calls, added: existing.added})
+	loop.check(ctx, calls)
 
-	return context.WithValue(ctx, contextKey, data)
+	return ctx
 }
 
 // Zap will wrap your Zap log fields with any available metadata from the given
@@ -86,15 +124,56 @@ func WithMeta(ctx context.Context, data Meta) context.Context {
 //     }
 //
 func Zap(ctx context.Context, fields ...zapcore.Field) []zapcore.Field {
-	value := ctx.Value(contextKey)
-	if value == nil {
+	meta, ok := fromContext(ctx)
+	if !ok {
 		return fields
 	}
 
-	casted, ok := value.(Meta)
+	return append(fields, zap.Object("context", meta))
+}
+
+// EncodeTo writes any metadata from the given context directly into the given
+// object encoder, without building any intermediate `zap.Field` values. This is
+// useful for custom cores and encoders which want to pull metadata out of a
+// context on their own hot path.
+//
+// The keys are written directly into the encoder, so if you want them nested
+// under a "context" key like `Zap` does, open a namespace first:
+//
+//    enc.OpenNamespace("context")
+//    err := logctx.EncodeTo(ctx, enc)
+//
+// If the given context was not decorated with `WithMeta` then nothing is
+// written and the returned error is nil.
+//
+func EncodeTo(ctx context.Context, enc zapcore.ObjectEncoder) error {
+	meta, ok := fromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	return meta.MarshalLogObject(enc)
+}
+
+// Snapshot returns a read-only copy of the metadata in the given context, which
+// is empty if the context was not decorated with `WithMeta`. Changes made to the
+// context after the snapshot was taken are not reflected in it.
+//
+func Snapshot(ctx context.Context) ReadOnlyMeta {
+	meta, ok := fromContext(ctx)
 	if !ok {
-		return fields
+		return ReadOnlyMeta{}
+	}
+
+	return ReadOnlyMeta{copyMeta(meta)}
+}
+
+func fromContext(ctx context.Context) (Meta, bool) {
+	// TrackAdded can store a value before any metadata has been added.
+	value, ok := ctx.Value(metaKey{}).(metadata)
+	if !ok || value.meta == nil {
+		return nil, false
 	}
 
-	return append(fields, zap.Object("context", casted))
+	return value.meta, true
 }
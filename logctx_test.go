@@ -3,7 +3,11 @@ package logctx_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
@@ -70,6 +74,51 @@ func TestContextNestedOverwrite(t *testing.T) {
 	a.Contains(buf.String(), `"deal_id":"overwrite context metadata"`)
 }
 
+// WithMeta used to merge a child's keys into the map shared with its parent,
+// so logging the parent afterwards included them. It now copies on write, and
+// this test pins that behaviour on purpose: callers which relied on seeing the
+// child's keys from the parent must use TrackAdded instead.
+func TestContextCopyOnWrite(t *testing.T) {
+	a := assert.New(t)
+	logger, buf := testLogger()
+
+	data := map[string]string{"request_id": "abc"}
+	root := logctx.WithMeta(context.Background(), data)
+
+	// a handler decorating its own context
+	child := logctx.WithMeta(root, map[string]string{"user_id": "southclaws"})
+
+	// a summary logged from the parent doesn't include the child's keys
+	logger.Info("request finished", logctx.Zap(root)...)
+	a.Contains(buf.String(), `"context":{"request_id":"abc"}`)
+	a.NotContains(buf.String(), `"user_id"`)
+
+	// and neither does the caller's map
+	a.Equal(map[string]string{"request_id": "abc"}, data)
+
+	a.Equal(logctx.Meta{"request_id": "abc", "user_id": "southclaws"}, logctx.Snapshot(child).Copy())
+}
+
+func TestContextConcurrent(t *testing.T) {
+	a := assert.New(t)
+
+	root := logctx.WithMeta(context.Background(), map[string]string{"service": "api"})
+
+	// decorating a shared context from many goroutines must not race
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx := logctx.WithMeta(root, map[string]string{"request_id": fmt.Sprint(i)})
+			logctx.Snapshot(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	a.Equal(logctx.Meta{"service": "api"}, logctx.Snapshot(root).Copy())
+}
+
 func TestContextEmpty(t *testing.T) {
 	a := assert.New(t)
 
@@ -86,3 +135,69 @@ func TestContextEmpty(t *testing.T) {
 	a.Contains(buf.String(), "message")
 	a.Contains(buf.String(), "hello")
 }
+
+func TestEncodeTo(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := logctx.WithMeta(context.Background(), map[string]string{"user_id": "southclaws"})
+
+	enc := zapcore.NewMapObjectEncoder()
+	a.NoError(logctx.EncodeTo(ctx, enc))
+
+	a.Equal(map[string]interface{}{"user_id": "southclaws"}, enc.Fields)
+}
+
+func TestEncodeToEmpty(t *testing.T) {
+	a := assert.New(t)
+
+	enc := zapcore.NewMapObjectEncoder()
+	a.NoError(logctx.EncodeTo(context.Background(), enc))
+
+	// no metadata means nothing is written
+	a.Empty(enc.Fields)
+}
+
+func FuzzContext(f *testing.F) {
+	f.Add("user_id", "southclaws")
+	f.Add("", "")
+	f.Add("context", `{"nested":"json"}`)
+	f.Add("quote\"d", "new\nline")
+	f.Add("\xff\xfe", "\x00")
+
+	f.Fuzz(func(t *testing.T, key, value string) {
+		logger, buf := testLogger()
+
+		ctx := logctx.WithMeta(context.Background(), map[string]string{key: value})
+
+		logger.Info("fuzz context", logctx.Zap(ctx)...)
+
+		// whatever the keys and values are, the entry must still be valid JSON
+		var entry struct {
+			Context map[string]string `json:"context"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid log entry %q: %v", buf.String(), err)
+		}
+
+		// invalid UTF-8 is replaced by the encoder, so only valid strings are
+		// expected to round-trip exactly.
+		if utf8.ValidString(key) && utf8.ValidString(value) {
+			if got := entry.Context[key]; got != value {
+				t.Fatalf("expected %q for key %q, got %q", value, key, got)
+			}
+		}
+	})
+}
+
+func TestSnapshot(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := logctx.WithMeta(context.Background(), map[string]string{"user_id": "southclaws"})
+	snapshot := logctx.Snapshot(ctx)
+
+	logctx.WithMeta(ctx, map[string]string{"deal_id": "xyz"})
+
+	// the snapshot is not affected by later changes
+	a.Equal(logctx.Meta{"user_id": "southclaws"}, snapshot.Copy())
+	a.Zero(logctx.Snapshot(context.Background()).Len())
+}
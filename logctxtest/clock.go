@@ -0,0 +1,44 @@
+// Package logctxtest provides test doubles for logctx.
+package logctxtest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a fake logctx.Clock which only moves when told to. It's safe for
+// concurrent use.
+//
+//	clock := logctxtest.NewClock(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+//	logctx.SetClock(clock)
+//	defer logctx.SetClock(nil)
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock returns a fake clock stopped at the given time.
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now returns the clock's current time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set moves the clock to t.
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
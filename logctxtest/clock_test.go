@@ -0,0 +1,29 @@
+package logctxtest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/logctx"
+	"github.com/Southclaws/logctx/logctxtest"
+)
+
+var _ logctx.Clock = (*logctxtest.Clock)(nil)
+
+func TestClock(t *testing.T) {
+	a := assert.New(t)
+
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := logctxtest.NewClock(start)
+
+	a.Equal(start, clock.Now())
+	a.Equal(start, clock.Now())
+
+	clock.Advance(time.Second)
+	a.Equal(start.Add(time.Second), clock.Now())
+
+	clock.Set(start)
+	a.Equal(start, clock.Now())
+}
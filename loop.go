@@ -0,0 +1,60 @@
+package logctx
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type loopHook struct {
+	threshold int
+	hook      func(ctx context.Context, calls int)
+}
+
+// loopDetector holds the active *loopHook, or a nil *loopHook when disabled.
+var loopDetector atomic.Value
+
+func init() {
+	loopDetector.Store((*loopHook)(nil))
+}
+
+// SetLoopHook turns on a heuristic detector for contexts which are decorated
+// with `WithMeta` an unusual number of times, which usually means it's being
+// called inside a loop:
+//
+//	for _, item := range items {
+//	    ctx = logctx.WithMeta(ctx, logctx.Meta{"item_id": item.ID})
+//	}
+//
+// Every call wraps the context once more, so this quietly bloats memory for as
+// long as the context lives. When a chain of WithMeta calls reaches threshold
+// the hook is called, once, with the context and the number of calls.
+//
+// Calls are only counted while a hook is set, so calls made before then are not
+// counted. A chain started earlier only reaches the threshold after that many
+// more calls once the hook is set.
+//
+// Passing a threshold of zero or less, or a nil hook, turns detection off.
+func SetLoopHook(threshold int, hook func(ctx context.Context, calls int)) {
+	if threshold <= 0 || hook == nil {
+		loopDetector.Store((*loopHook)(nil))
+		return
+	}
+
+	loopDetector.Store(&loopHook{threshold: threshold, hook: hook})
+}
+
+// count returns the call count for a new layer of metadata on top of a parent
+// with the given count, or zero if detection is turned off.
+func (l *loopHook) count(parent int) int {
+	if l == nil {
+		return 0
+	}
+	return parent + 1
+}
+
+// check fires the hook when the given count reaches the threshold.
+func (l *loopHook) check(ctx context.Context, calls int) {
+	if l != nil && calls == l.threshold {
+		l.hook(ctx, calls)
+	}
+}
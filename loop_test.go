@@ -0,0 +1,89 @@
+package logctx_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/logctx"
+)
+
+func TestLoopHook(t *testing.T) {
+	a := assert.New(t)
+
+	fired := []int{}
+	logctx.SetLoopHook(100, func(ctx context.Context, calls int) {
+		fired = append(fired, calls)
+	})
+	defer logctx.SetLoopHook(0, nil)
+
+	ctx := context.Background()
+	for i := 0; i < 250; i++ {
+		ctx = logctx.WithMeta(ctx, logctx.Meta{"item_id": fmt.Sprint(i)})
+	}
+
+	// the hook only fires once per chain, when the threshold is reached
+	a.Equal([]int{100}, fired)
+
+	v, _ := logctx.Snapshot(ctx).Get("item_id")
+	a.Equal("249", v)
+}
+
+func TestLoopHookBelowThreshold(t *testing.T) {
+	a := assert.New(t)
+
+	fired := false
+	logctx.SetLoopHook(3, func(ctx context.Context, calls int) {
+		fired = true
+	})
+	defer logctx.SetLoopHook(0, nil)
+
+	// separate chains from the same root are counted separately
+	root := logctx.WithMeta(context.Background(), logctx.Meta{"user_id": "southclaws"})
+	logctx.WithMeta(root, logctx.Meta{"deal_id": "xyz"})
+	logctx.WithMeta(root, logctx.Meta{"deal_id": "abc"})
+
+	a.False(fired)
+}
+
+func TestLoopHookStartedBefore(t *testing.T) {
+	a := assert.New(t)
+
+	// calls made before the hook is set are not counted
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		ctx = logctx.WithMeta(ctx, logctx.Meta{"item_id": fmt.Sprint(i)})
+	}
+
+	fired := 0
+	logctx.SetLoopHook(3, func(ctx context.Context, calls int) {
+		fired = calls
+	})
+	defer logctx.SetLoopHook(0, nil)
+
+	ctx = logctx.WithMeta(ctx, logctx.Meta{"item_id": "5"})
+	ctx = logctx.WithMeta(ctx, logctx.Meta{"item_id": "6"})
+	a.Zero(fired)
+
+	logctx.WithMeta(ctx, logctx.Meta{"item_id": "7"})
+	a.Equal(3, fired)
+}
+
+func TestLoopHookNoExtraLayers(t *testing.T) {
+	a := assert.New(t)
+
+	root := logctx.WithMeta(context.Background(), logctx.Meta{"user_id": "southclaws"})
+	data := logctx.Meta{"deal_id": "xyz"}
+
+	without := testing.AllocsPerRun(100, func() { logctx.WithMeta(root, data) })
+
+	logctx.SetLoopHook(1000, func(ctx context.Context, calls int) {})
+	defer logctx.SetLoopHook(0, nil)
+
+	with := testing.AllocsPerRun(100, func() { logctx.WithMeta(root, data) })
+
+	// counting must not wrap the context in another layer
+	a.Equal(without, with)
+}
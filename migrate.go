@@ -0,0 +1,110 @@
+package logctx
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// MetaFromZapFields converts a bundle of zap fields into Meta, to ease moving a
+// codebase from passing `[]zap.Field` around to decorating contexts instead:
+//
+//	ctx = logctx.WithMeta(ctx, logctx.MetaFromZapFields(fields...))
+//
+// The conversion is best-effort. Strings, stringers, booleans, numbers,
+// durations, times, byte strings and errors are converted to their string form.
+// Fields which have no sensible string form, such as objects, arrays, binary
+// and reflected values, are skipped.
+func MetaFromZapFields(fields ...zap.Field) Meta {
+	meta := make(Meta, len(fields))
+	for _, f := range fields {
+		if v, ok := fieldString(f); ok {
+			meta[f.Key] = v
+		}
+	}
+	return meta
+}
+
+func fieldString(f zap.Field) (string, bool) {
+	switch f.Type {
+	case zapcore.StringType:
+		return f.String, true
+
+	case zapcore.ByteStringType:
+		b, ok := f.Interface.([]byte)
+		if !ok {
+			return "", false
+		}
+		return string(b), true
+
+	case zapcore.StringerType:
+		s, ok := f.Interface.(fmt.Stringer)
+		if !ok {
+			return "", false
+		}
+		return safeString(s, s.String)
+
+	case zapcore.ErrorType:
+		err, ok := f.Interface.(error)
+		if !ok {
+			return "", false
+		}
+		return safeString(err, err.Error)
+
+	case zapcore.BoolType:
+		return strconv.FormatBool(f.Integer == 1), true
+
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
+		return strconv.FormatInt(f.Integer, 10), true
+
+	case zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.UintptrType:
+		return strconv.FormatUint(uint64(f.Integer), 10), true
+
+	case zapcore.Float64Type:
+		return strconv.FormatFloat(math.Float64frombits(uint64(f.Integer)), 'g', -1, 64), true
+
+	case zapcore.Float32Type:
+		return strconv.FormatFloat(float64(math.Float32frombits(uint32(f.Integer))), 'g', -1, 32), true
+
+	case zapcore.DurationType:
+		return time.Duration(f.Integer).String(), true
+
+	case zapcore.TimeType:
+		t := time.Unix(0, f.Integer)
+		if loc, ok := f.Interface.(*time.Location); ok {
+			t = t.In(loc)
+		}
+		return t.Format(time.RFC3339Nano), true
+
+	case zapcore.TimeFullType:
+		t, ok := f.Interface.(time.Time)
+		if !ok {
+			return "", false
+		}
+		return t.Format(time.RFC3339Nano), true
+	}
+
+	return "", false
+}
+
+// safeString calls fn, which is a String or Error method on v, recovering from
+// any panic the same way zap does when encoding these fields. Typed nil
+// pointers become "<nil>" and any other panic skips the field.
+func safeString(v interface{}, fn func() string) (s string, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+				s, ok = "<nil>", true
+				return
+			}
+			s, ok = "", false
+		}
+	}()
+
+	return fn(), true
+}
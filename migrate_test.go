@@ -0,0 +1,87 @@
+package logctx_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/logctx"
+)
+
+func TestMetaFromZapFields(t *testing.T) {
+	a := assert.New(t)
+
+	at := time.Date(2022, 8, 1, 12, 30, 0, 0, time.UTC)
+
+	meta := logctx.MetaFromZapFields(
+		zap.String("user_id", "southclaws"),
+		zap.ByteString("bytes", []byte("raw")),
+		zap.Stringer("ip", net.IPv4(127, 0, 0, 1)),
+		zap.Error(errors.New("failed")),
+		zap.Bool("admin", true),
+		zap.Int("count", -3),
+		zap.Uint8("small", 7),
+		zap.Float64("ratio", 0.25),
+		zap.Float32("ratio32", 1.5),
+		zap.Duration("elapsed", 1500*time.Millisecond),
+		zap.Time("at", at),
+	)
+
+	a.Equal(logctx.Meta{
+		"user_id": "southclaws",
+		"bytes":   "raw",
+		"ip":      "127.0.0.1",
+		"error":   "failed",
+		"admin":   "true",
+		"count":   "-3",
+		"small":   "7",
+		"ratio":   "0.25",
+		"ratio32": "1.5",
+		"elapsed": "1.5s",
+		"at":      "2022-08-01T12:30:00Z",
+	}, meta)
+}
+
+func TestMetaFromZapFieldsSkipped(t *testing.T) {
+	a := assert.New(t)
+
+	meta := logctx.MetaFromZapFields(
+		zap.Strings("tags", []string{"a", "b"}),
+		zap.Any("object", struct{ A int }{1}),
+		zap.Binary("binary", []byte{0x00}),
+		zap.Skip(),
+	)
+
+	a.Empty(meta)
+}
+
+type nilStringer struct{ name string }
+
+func (n *nilStringer) String() string { return n.name }
+
+type nilError struct{ msg string }
+
+func (n *nilError) Error() string { return n.msg }
+
+type panicStringer struct{}
+
+func (panicStringer) String() string { panic("failed") }
+
+func TestMetaFromZapFieldsPanics(t *testing.T) {
+	a := assert.New(t)
+
+	var err *nilError
+
+	meta := logctx.MetaFromZapFields(
+		zap.Stringer("stringer", (*nilStringer)(nil)),
+		zap.NamedError("error", err),
+		zap.Stringer("panics", panicStringer{}),
+	)
+
+	// typed nils are logged the same way zap logs them, other panics are skipped
+	a.Equal(logctx.Meta{"stringer": "<nil>", "error": "<nil>"}, meta)
+}
@@ -0,0 +1,132 @@
+package logctx
+
+import (
+	"go.uber.org/multierr"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewLevelPartitionedCore builds a core which writes entries below the given
+// level to low and entries at or above it to high. This is the usual way to
+// split JSON lines between stdout and stderr for container platforms which
+// treat those streams differently:
+//
+//	core := logctx.NewLevelPartitionedCore(enc, zap.WarnLevel, os.Stdout, os.Stderr)
+func NewLevelPartitionedCore(enc zapcore.Encoder, level zapcore.Level, low, high zapcore.WriteSyncer) zapcore.Core {
+	return zapcore.NewTee(
+		zapcore.NewCore(enc.Clone(), low, zapcore.LevelEnabler(levelBelow(level))),
+		zapcore.NewCore(enc.Clone(), high, level),
+	)
+}
+
+type levelBelow zapcore.Level
+
+func (l levelBelow) Enabled(level zapcore.Level) bool {
+	return level < zapcore.Level(l)
+}
+
+// NewPartitionedCore builds a core which routes each entry to a writer based on
+// the value of the given metadata key, as added by `WithMeta` and emitted by
+// `Zap`, or in a `ReadOnlyMeta` logged under "context". Entries with no metadata, without the key or with a value that isn't
+// in routes are written to fallback.
+//
+// For example, to send audit entries to file descriptor 3 and everything else
+// to stdout:
+//
+//	core := logctx.NewPartitionedCore(enc, zap.InfoLevel, "kind",
+//	    map[string]zapcore.WriteSyncer{"audit": os.NewFile(3, "audit")},
+//	    os.Stdout,
+//	)
+func NewPartitionedCore(enc zapcore.Encoder, enab zapcore.LevelEnabler, key string, routes map[string]zapcore.WriteSyncer, fallback zapcore.WriteSyncer) zapcore.Core {
+	cores := make(map[string]zapcore.Core, len(routes))
+	for value, ws := range routes {
+		cores[value] = zapcore.NewCore(enc.Clone(), ws, enab)
+	}
+
+	return &partitionedCore{
+		LevelEnabler: enab,
+		key:          key,
+		routes:       cores,
+		fallback:     zapcore.NewCore(enc.Clone(), fallback, enab),
+	}
+}
+
+type partitionedCore struct {
+	zapcore.LevelEnabler
+	key      string
+	routes   map[string]zapcore.Core
+	fallback zapcore.Core
+
+	// route holds the value of key from metadata added through With, if any.
+	route    string
+	hasRoute bool
+}
+
+func (c *partitionedCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &partitionedCore{
+		LevelEnabler: c.LevelEnabler,
+		key:          c.key,
+		routes:       make(map[string]zapcore.Core, len(c.routes)),
+		fallback:     c.fallback.With(fields),
+		route:        c.route,
+		hasRoute:     c.hasRoute,
+	}
+	for value, core := range c.routes {
+		clone.routes[value] = core.With(fields)
+	}
+
+	if route, ok := c.routeFor(fields); ok {
+		clone.route, clone.hasRoute = route, true
+	}
+
+	return clone
+}
+
+func (c *partitionedCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *partitionedCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	route, ok := c.routeFor(fields)
+	if !ok {
+		route, ok = c.route, c.hasRoute
+	}
+
+	if core, exists := c.routes[route]; ok && exists {
+		return core.Write(ent, fields)
+	}
+
+	return c.fallback.Write(ent, fields)
+}
+
+func (c *partitionedCore) Sync() error {
+	err := c.fallback.Sync()
+	for _, core := range c.routes {
+		err = multierr.Append(err, core.Sync())
+	}
+	return err
+}
+
+// routeFor finds the value of the partition key in any metadata field.
+func (c *partitionedCore) routeFor(fields []zapcore.Field) (string, bool) {
+	for _, f := range fields {
+		if f.Type != zapcore.ObjectMarshalerType || f.Key != "context" {
+			continue
+		}
+
+		switch meta := f.Interface.(type) {
+		case Meta:
+			if value, ok := meta[c.key]; ok {
+				return value, true
+			}
+		case ReadOnlyMeta:
+			if value, ok := meta.Get(c.key); ok {
+				return value, true
+			}
+		}
+	}
+
+	return "", false
+}
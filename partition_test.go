@@ -0,0 +1,93 @@
+package logctx_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/Southclaws/logctx"
+)
+
+func testEncoder() zapcore.Encoder {
+	return zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+}
+
+func TestLevelPartitionedCore(t *testing.T) {
+	a := assert.New(t)
+
+	stdout, stderr := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+	logger := zap.New(logctx.NewLevelPartitionedCore(testEncoder(), zap.WarnLevel, zapcore.AddSync(stdout), zapcore.AddSync(stderr)))
+
+	logger.Info("informational")
+	logger.Error("erroneous")
+
+	a.Contains(stdout.String(), "informational")
+	a.NotContains(stdout.String(), "erroneous")
+	a.Contains(stderr.String(), "erroneous")
+	a.NotContains(stderr.String(), "informational")
+}
+
+func TestPartitionedCore(t *testing.T) {
+	a := assert.New(t)
+
+	audit, fallback := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+	logger := zap.New(logctx.NewPartitionedCore(testEncoder(), zap.DebugLevel, "kind",
+		map[string]zapcore.WriteSyncer{"audit": zapcore.AddSync(audit)},
+		zapcore.AddSync(fallback),
+	))
+
+	auditCtx := logctx.WithMeta(context.Background(), logctx.Meta{"kind": "audit"})
+	otherCtx := logctx.WithMeta(context.Background(), logctx.Meta{"kind": "other"})
+
+	logger.Info("audited", logctx.Zap(auditCtx)...)
+	logger.Info("unknown kind", logctx.Zap(otherCtx)...)
+	logger.Info("no context")
+
+	a.Contains(audit.String(), "audited")
+	a.NotContains(audit.String(), "unknown kind")
+	a.NotContains(audit.String(), "no context")
+
+	a.NotContains(fallback.String(), "audited")
+	a.Contains(fallback.String(), "unknown kind")
+	a.Contains(fallback.String(), "no context")
+}
+
+func TestPartitionedCoreWith(t *testing.T) {
+	a := assert.New(t)
+
+	audit, fallback := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+	logger := zap.New(logctx.NewPartitionedCore(testEncoder(), zap.DebugLevel, "kind",
+		map[string]zapcore.WriteSyncer{"audit": zapcore.AddSync(audit)},
+		zapcore.AddSync(fallback),
+	))
+
+	ctx := logctx.WithMeta(context.Background(), logctx.Meta{"kind": "audit"})
+
+	// metadata attached to the logger routes every entry written through it
+	logger.With(logctx.Zap(ctx)...).Info("audited")
+
+	a.Contains(audit.String(), "audited")
+	a.Contains(audit.String(), `"context":{"kind":"audit"}`)
+	a.Empty(fallback.String())
+}
+
+func TestPartitionedCoreReadOnlyMeta(t *testing.T) {
+	a := assert.New(t)
+
+	audit, fallback := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+	logger := zap.New(logctx.NewPartitionedCore(testEncoder(), zap.DebugLevel, "kind",
+		map[string]zapcore.WriteSyncer{"audit": zapcore.AddSync(audit)},
+		zapcore.AddSync(fallback),
+	))
+
+	ctx := logctx.WithMeta(context.Background(), logctx.Meta{"kind": "audit"})
+
+	logger.Info("audited", zap.Object("context", logctx.Snapshot(ctx)))
+
+	a.Contains(audit.String(), "audited")
+	a.Empty(fallback.String())
+}
@@ -0,0 +1,70 @@
+package logctx
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Priority decides which metadata keys are kept when something has to be
+// dropped, such as when propagated metadata would exceed `MaxPropagationSize`
+// or `MaxPropagationKeys`. Higher priority keys are always kept first.
+type Priority int
+
+const (
+	// PriorityDebug is for keys which are only useful while debugging and are
+	// the first to be dropped.
+	PriorityDebug Priority = -1
+	// PriorityNormal is the priority of any key which hasn't been given one.
+	PriorityNormal Priority = 0
+	// PriorityCritical is for keys which must survive whenever possible, such
+	// as request IDs which tie entries together across services.
+	PriorityCritical Priority = 1
+)
+
+var (
+	prioritiesMu sync.Mutex
+	// priorities holds a map[string]Priority which is replaced, never modified,
+	// so it can be read without locking.
+	priorities atomic.Value
+)
+
+func init() {
+	priorities.Store(map[string]Priority{"request_id": PriorityCritical})
+}
+
+// SetPriority assigns a priority to a metadata key. By default every key is
+// `PriorityNormal`, except "request_id" which is `PriorityCritical`.
+//
+//	logctx.SetPriority("trace_id", logctx.PriorityCritical)
+//	logctx.SetPriority("user_agent", logctx.PriorityDebug)
+func SetPriority(key string, p Priority) {
+	prioritiesMu.Lock()
+	defer prioritiesMu.Unlock()
+
+	current := priorities.Load().(map[string]Priority)
+	next := make(map[string]Priority, len(current)+1)
+	for k, v := range current {
+		next[k] = v
+	}
+	next[key] = p
+
+	priorities.Store(next)
+}
+
+// KeyPriority returns the priority assigned to a metadata key.
+func KeyPriority(key string) Priority {
+	return priorities.Load().(map[string]Priority)[key]
+}
+
+// sortByPriority sorts keys from highest to lowest priority, and by name within
+// the same priority so the order is deterministic.
+func sortByPriority(keys []string) {
+	p := priorities.Load().(map[string]Priority)
+	sort.Slice(keys, func(i, j int) bool {
+		if p[keys[i]] != p[keys[j]] {
+			return p[keys[i]] > p[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+}
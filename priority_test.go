@@ -0,0 +1,81 @@
+package logctx_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/logctx"
+)
+
+func TestKeyPriority(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal(logctx.PriorityCritical, logctx.KeyPriority("request_id"))
+	a.Equal(logctx.PriorityNormal, logctx.KeyPriority("user_id"))
+
+	logctx.SetPriority("user_agent", logctx.PriorityDebug)
+	defer logctx.SetPriority("user_agent", logctx.PriorityNormal)
+
+	a.Equal(logctx.PriorityDebug, logctx.KeyPriority("user_agent"))
+}
+
+func TestPriorityInjectKeyLimit(t *testing.T) {
+	a := assert.New(t)
+
+	// request_id sorts after every other key, but is critical so it's kept
+	meta := logctx.Meta{"request_id": "abc"}
+	for i := 0; i < logctx.MaxPropagationKeys; i++ {
+		meta[fmt.Sprintf("key_%03d", i)] = "value"
+	}
+
+	carrier := logctx.MapCarrier{}
+	logctx.Inject(logctx.WithMeta(context.Background(), meta), carrier)
+
+	extracted := logctx.Snapshot(logctx.Extract(context.Background(), carrier))
+	v, ok := extracted.Get("request_id")
+	a.True(ok)
+	a.Equal("abc", v)
+
+	// the last normal key made way for it
+	_, ok = extracted.Get(fmt.Sprintf("key_%03d", logctx.MaxPropagationKeys-1))
+	a.False(ok)
+}
+
+func TestPriorityInjectSizeLimit(t *testing.T) {
+	a := assert.New(t)
+
+	logctx.SetPriority("aa_debug", logctx.PriorityDebug)
+	defer logctx.SetPriority("aa_debug", logctx.PriorityNormal)
+
+	// both values fit on their own but not together, so the debug key goes
+	half := strings.Repeat("x", logctx.MaxPropagationSize/2)
+	meta := logctx.Meta{"aa_debug": half, "zz_normal": half}
+
+	carrier := logctx.MapCarrier{}
+	logctx.Inject(logctx.WithMeta(context.Background(), meta), carrier)
+
+	extracted := logctx.Snapshot(logctx.Extract(context.Background(), carrier))
+	_, ok := extracted.Get("zz_normal")
+	a.True(ok)
+	_, ok = extracted.Get("aa_debug")
+	a.False(ok)
+}
+
+func TestPriorityExtractKeyLimit(t *testing.T) {
+	a := assert.New(t)
+
+	pairs := []string{"request_id=abc"}
+	for i := 0; i < logctx.MaxPropagationKeys; i++ {
+		pairs = append(pairs, fmt.Sprintf("key_%03d=v", i))
+	}
+	carrier := logctx.MapCarrier{logctx.PropagationKey: strings.Join(pairs, "&")}
+
+	extracted := logctx.Snapshot(logctx.Extract(context.Background(), carrier))
+
+	v, _ := extracted.Get("request_id")
+	a.Equal("abc", v)
+}
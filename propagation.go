@@ -0,0 +1,248 @@
+package logctx
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// PropagationKey is the carrier key that metadata is injected into and
+	// extracted from. For HTTP this is the header name.
+	PropagationKey = "logctx-meta"
+
+	// MaxPropagationSize is the largest encoded value, in bytes, that `Inject`
+	// writes and `Extract` accepts. It keeps well within the header size limits
+	// of common servers and proxies, which usually start at 8KB for all headers.
+	MaxPropagationSize = 4096
+
+	// MaxPropagationKeys is the most keys that `Inject` writes and `Extract`
+	// accepts.
+	MaxPropagationKeys = 64
+
+	// TruncatedKey is the metadata key which marks that propagated metadata had
+	// keys dropped to fit within the limits above. Its value is the number of
+	// keys dropped, across every hop.
+	TruncatedKey = "logctx_truncated"
+)
+
+// Carrier is the storage medium used to propagate metadata across process
+// boundaries, such as HTTP headers or message attributes. It mirrors the
+// OpenTelemetry `propagation.TextMapCarrier` interface so that carriers written
+// for one will usually satisfy the other.
+//
+// Implement it once for any transport and `Inject` and `Extract` will work
+// through it.
+type Carrier interface {
+	// Get returns the value associated with the key, or an empty string.
+	Get(key string) string
+	// Set stores the key-value pair.
+	Set(key, value string)
+	// Keys lists the keys stored in this carrier.
+	Keys() []string
+}
+
+// Inject writes the metadata from the given context into the carrier so it can
+// be sent along with an outgoing request or message. If the context was not
+// decorated with `WithMeta`, the carrier is left alone.
+//
+// At most `MaxPropagationKeys` keys and `MaxPropagationSize` bytes are written,
+// so propagation never causes a request to be rejected for oversized headers.
+// Keys are added from highest to lowest `Priority`, so lower priority keys are
+// dropped first, and any pair that doesn't fit is dropped. When keys are
+// dropped, the number dropped is written under `TruncatedKey`.
+//
+//	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+//	logctx.Inject(ctx, logctx.HeaderCarrier(req.Header))
+func Inject(ctx context.Context, carrier Carrier) {
+	meta, ok := fromContext(ctx)
+	if !ok || len(meta) == 0 {
+		return
+	}
+
+	// A marker from an earlier hop is carried forward by adding to its count
+	// rather than being propagated like any other key.
+	previous, _ := strconv.Atoi(meta[TruncatedKey])
+
+	keys := make([]string, 0, len(meta))
+	for k := range meta {
+		if k != TruncatedKey {
+			keys = append(keys, k)
+		}
+	}
+	sortByPriority(keys)
+
+	encoded, dropped := encodeWithin(meta, keys, MaxPropagationSize, MaxPropagationKeys)
+	if dropped > 0 || previous > 0 {
+		// Make room for the marker, which can only need more room than it would
+		// for the number of keys dropped the first time round.
+		marker := "&" + TruncatedKey + "=" + strconv.Itoa(previous+len(meta))
+		encoded, dropped = encodeWithin(meta, keys, MaxPropagationSize-len(marker), MaxPropagationKeys-1)
+
+		if encoded != "" {
+			encoded += "&"
+		}
+		encoded += TruncatedKey + "=" + strconv.Itoa(previous+dropped)
+	}
+
+	if encoded == "" {
+		return
+	}
+
+	carrier.Set(PropagationKey, encoded)
+}
+
+// encodeWithin encodes as many of the given keys as possible, in order, within
+// the size and key limits. It returns the encoded pairs and how many keys were
+// left out.
+func encodeWithin(meta Meta, keys []string, maxSize, maxKeys int) (string, int) {
+	var b strings.Builder
+	written := 0
+	for _, k := range keys {
+		if written == maxKeys {
+			break
+		}
+
+		pair := url.QueryEscape(k) + "=" + url.QueryEscape(meta[k])
+		size := len(pair)
+		if written > 0 {
+			size++
+		}
+		if b.Len()+size > maxSize {
+			continue
+		}
+
+		if written > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(pair)
+		written++
+	}
+
+	return b.String(), len(keys) - written
+}
+
+// Extract reads any metadata from the carrier, as written by `Inject`, and adds
+// it to a copy of the given context with `WithMeta`. If the carrier holds no
+// metadata the context is returned unmodified. Malformed pairs are skipped.
+//
+// The carrier's contents usually come from an untrusted remote caller, so
+// values longer than `MaxPropagationSize` are ignored entirely and only the
+// `MaxPropagationKeys` highest `Priority` keys are kept. Either way, the number
+// of keys dropped, plus any dropped by the sender, is added under
+// `TruncatedKey`.
+//
+// When a key is present both in the given context and in the carrier, the value
+// already in the context wins. Metadata set locally, such as by authentication
+// middleware, is never overwritten by values sent by a remote caller.
+//
+//	ctx := logctx.Extract(r.Context(), logctx.HeaderCarrier(r.Header))
+func Extract(ctx context.Context, carrier Carrier) context.Context {
+	encoded := carrier.Get(PropagationKey)
+	if encoded == "" {
+		return ctx
+	}
+
+	local, _ := fromContext(ctx)
+
+	if len(encoded) > MaxPropagationSize {
+		// Count the pairs without parsing them, so it's only a rough count.
+		return withLocal(ctx, local, Meta{TruncatedKey: strconv.Itoa(strings.Count(encoded, "&") + 1)})
+	}
+
+	// ParseQuery keeps every pair it could decode alongside the first error, so
+	// the error can be ignored to get a best-effort result.
+	values, _ := url.ParseQuery(encoded)
+	if len(values) == 0 {
+		return ctx
+	}
+
+	previous, _ := strconv.Atoi(values.Get(TruncatedKey))
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		if k != TruncatedKey {
+			keys = append(keys, k)
+		}
+	}
+	sortByPriority(keys)
+
+	dropped := 0
+	if len(keys) > MaxPropagationKeys {
+		dropped = len(keys) - MaxPropagationKeys
+		keys = keys[:MaxPropagationKeys]
+	}
+
+	meta := make(Meta, len(keys)+1)
+	for _, k := range keys {
+		meta[k] = values.Get(k)
+	}
+	if previous+dropped > 0 {
+		meta[TruncatedKey] = strconv.Itoa(previous + dropped)
+	}
+
+	return withLocal(ctx, local, meta)
+}
+
+// withLocal adds the remote metadata to ctx, except for any keys already set
+// locally.
+func withLocal(ctx context.Context, local, remote Meta) context.Context {
+	for k := range remote {
+		if _, exists := local[k]; exists {
+			delete(remote, k)
+		}
+	}
+
+	if len(remote) == 0 {
+		return ctx
+	}
+
+	return WithMeta(ctx, remote)
+}
+
+// MapCarrier is a Carrier backed by a plain map, useful for message formats
+// with string attributes and for tests.
+type MapCarrier map[string]string
+
+// Get implements Carrier.
+func (c MapCarrier) Get(key string) string {
+	return c[key]
+}
+
+// Set implements Carrier.
+func (c MapCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Keys implements Carrier.
+func (c MapCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// HeaderCarrier is a Carrier backed by HTTP headers.
+type HeaderCarrier http.Header
+
+// Get implements Carrier.
+func (c HeaderCarrier) Get(key string) string {
+	return http.Header(c).Get(key)
+}
+
+// Set implements Carrier.
+func (c HeaderCarrier) Set(key, value string) {
+	http.Header(c).Set(key, value)
+}
+
+// Keys implements Carrier.
+func (c HeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
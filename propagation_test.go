@@ -0,0 +1,186 @@
+package logctx_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/logctx"
+)
+
+func TestPropagationMap(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := logctx.WithMeta(context.Background(), map[string]string{"user_id": "southclaws", "deal_id": "x y&z=1"})
+
+	carrier := logctx.MapCarrier{}
+	logctx.Inject(ctx, carrier)
+
+	a.Equal([]string{logctx.PropagationKey}, carrier.Keys())
+
+	extracted := logctx.Extract(context.Background(), carrier)
+
+	a.Equal(logctx.Meta{"user_id": "southclaws", "deal_id": "x y&z=1"}, logctx.Snapshot(extracted).Copy())
+}
+
+func TestPropagationHeader(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := logctx.WithMeta(context.Background(), map[string]string{"user_id": "southclaws"})
+
+	header := http.Header{}
+	logctx.Inject(ctx, logctx.HeaderCarrier(header))
+
+	a.Equal("user_id=southclaws", header.Get("Logctx-Meta"))
+
+	// extracting merges with whatever the receiving context already has
+	root := logctx.WithMeta(context.Background(), map[string]string{"service": "api"})
+	extracted := logctx.Extract(root, logctx.HeaderCarrier(header))
+
+	a.Equal(logctx.Meta{"user_id": "southclaws", "service": "api"}, logctx.Snapshot(extracted).Copy())
+
+	// the receiving context itself is left alone
+	a.Equal(logctx.Meta{"service": "api"}, logctx.Snapshot(root).Copy())
+}
+
+func TestPropagationConflict(t *testing.T) {
+	a := assert.New(t)
+
+	carrier := logctx.MapCarrier{logctx.PropagationKey: "user_id=impostor&deal_id=xyz"}
+
+	// locally set keys win over remote ones
+	root := logctx.WithMeta(context.Background(), map[string]string{"user_id": "southclaws"})
+	extracted := logctx.Extract(root, carrier)
+
+	a.Equal(logctx.Meta{"user_id": "southclaws", "deal_id": "xyz"}, logctx.Snapshot(extracted).Copy())
+}
+
+func TestPropagationEmpty(t *testing.T) {
+	a := assert.New(t)
+
+	carrier := logctx.MapCarrier{}
+	logctx.Inject(context.Background(), carrier)
+
+	a.Empty(carrier)
+
+	root := context.Background()
+	a.Equal(root, logctx.Extract(root, carrier))
+}
+
+func TestPropagationMalformed(t *testing.T) {
+	a := assert.New(t)
+
+	carrier := logctx.MapCarrier{logctx.PropagationKey: "user_id=southclaws&bad=%zz"}
+
+	extracted := logctx.Extract(context.Background(), carrier)
+
+	a.Equal(logctx.Meta{"user_id": "southclaws"}, logctx.Snapshot(extracted).Copy())
+}
+
+func TestPropagationInjectLimits(t *testing.T) {
+	a := assert.New(t)
+
+	meta := logctx.Meta{"huge": strings.Repeat("x", logctx.MaxPropagationSize)}
+	for i := 0; i < logctx.MaxPropagationKeys*2; i++ {
+		meta[fmt.Sprintf("key_%03d", i)] = "value"
+	}
+
+	carrier := logctx.MapCarrier{}
+	logctx.Inject(logctx.WithMeta(context.Background(), meta), carrier)
+
+	encoded := carrier.Get(logctx.PropagationKey)
+	a.LessOrEqual(len(encoded), logctx.MaxPropagationSize)
+
+	// the oversized value is dropped and the rest are capped, leaving room for
+	// the marker
+	extracted := logctx.Snapshot(logctx.Extract(context.Background(), carrier))
+	a.Equal(logctx.MaxPropagationKeys, extracted.Len())
+	a.Equal("key_000", extracted.Keys()[0])
+	_, ok := extracted.Get("huge")
+	a.False(ok)
+
+	dropped, _ := extracted.Get(logctx.TruncatedKey)
+	a.Equal(fmt.Sprint(len(meta)-(logctx.MaxPropagationKeys-1)), dropped)
+}
+
+func TestPropagationInjectNoMarker(t *testing.T) {
+	a := assert.New(t)
+
+	carrier := logctx.MapCarrier{}
+	logctx.Inject(logctx.WithMeta(context.Background(), logctx.Meta{"user_id": "southclaws"}), carrier)
+
+	// nothing was dropped, so there's no marker
+	a.NotContains(carrier.Get(logctx.PropagationKey), logctx.TruncatedKey)
+}
+
+func TestPropagationMarkerAcrossHops(t *testing.T) {
+	a := assert.New(t)
+
+	// a marker from an earlier hop is counted on
+	ctx := logctx.WithMeta(context.Background(), logctx.Meta{
+		logctx.TruncatedKey: "3",
+		"huge":              strings.Repeat("x", logctx.MaxPropagationSize),
+		"user_id":           "southclaws",
+	})
+
+	carrier := logctx.MapCarrier{}
+	logctx.Inject(ctx, carrier)
+
+	extracted := logctx.Snapshot(logctx.Extract(context.Background(), carrier))
+	dropped, _ := extracted.Get(logctx.TruncatedKey)
+	a.Equal("4", dropped)
+	v, _ := extracted.Get("user_id")
+	a.Equal("southclaws", v)
+}
+
+func TestPropagationExtractLimits(t *testing.T) {
+	a := assert.New(t)
+
+	// oversized values are ignored entirely, apart from the marker
+	carrier := logctx.MapCarrier{logctx.PropagationKey: "user_id=" + strings.Repeat("x", logctx.MaxPropagationSize) + "&deal_id=xyz"}
+	root := context.Background()
+	a.Equal(logctx.Meta{logctx.TruncatedKey: "2"}, logctx.Snapshot(logctx.Extract(root, carrier)).Copy())
+
+	pairs := []string{}
+	for i := 0; i < logctx.MaxPropagationKeys*2; i++ {
+		pairs = append(pairs, fmt.Sprintf("key_%03d=v", i))
+	}
+	carrier = logctx.MapCarrier{logctx.PropagationKey: strings.Join(pairs, "&")}
+
+	extracted := logctx.Snapshot(logctx.Extract(root, carrier))
+	a.Equal(logctx.MaxPropagationKeys+1, extracted.Len())
+	a.Equal(fmt.Sprintf("key_%03d", logctx.MaxPropagationKeys-1), extracted.Keys()[logctx.MaxPropagationKeys-1])
+
+	dropped, _ := extracted.Get(logctx.TruncatedKey)
+	a.Equal(fmt.Sprint(logctx.MaxPropagationKeys), dropped)
+}
+
+func FuzzExtract(f *testing.F) {
+	f.Add("user_id=southclaws&deal_id=xyz")
+	f.Add("")
+	f.Add("&&==&")
+	f.Add("bad=%zz&user_id=%E2%9C%93")
+	f.Add("a=1;b=2")
+	f.Add(strings.Repeat("k=v&", 100))
+
+	f.Fuzz(func(t *testing.T, encoded string) {
+		carrier := logctx.MapCarrier{logctx.PropagationKey: encoded}
+
+		extracted := logctx.Snapshot(logctx.Extract(context.Background(), carrier))
+		// plus one for the marker
+		if extracted.Len() > logctx.MaxPropagationKeys+1 {
+			t.Fatalf("extracted %d keys, more than the limit", extracted.Len())
+		}
+
+		// re-encoding can grow values, but never past the limit
+		reinjected := logctx.MapCarrier{}
+		logctx.Inject(logctx.WithMeta(context.Background(), extracted.Copy()), reinjected)
+		if len(reinjected.Get(logctx.PropagationKey)) > logctx.MaxPropagationSize {
+			t.Fatalf("injected more than the size limit")
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package logctx
+
+import (
+	"sort"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ReadOnlyMeta is a view of metadata which can be read but not modified. It's
+// returned by accessors such as `Snapshot` so that downstream packages can
+// consume metadata without being able to corrupt the state shared by other
+// contexts. The zero value is an empty view.
+type ReadOnlyMeta struct {
+	m Meta
+}
+
+// Get returns the value for the given key and whether it was present.
+func (r ReadOnlyMeta) Get(key string) (string, bool) {
+	v, ok := r.m[key]
+	return v, ok
+}
+
+// Len returns the number of keys.
+func (r ReadOnlyMeta) Len() int {
+	return len(r.m)
+}
+
+// Keys returns all of the keys, sorted.
+func (r ReadOnlyMeta) Keys() []string {
+	keys := make([]string, 0, len(r.m))
+	for k := range r.m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Range calls fn for each key and value, in no particular order, until fn
+// returns false.
+func (r ReadOnlyMeta) Range(fn func(key, value string) bool) {
+	for k, v := range r.m {
+		if !fn(k, v) {
+			return
+		}
+	}
+}
+
+// Copy returns a new Meta with the same keys and values, which the caller is
+// free to modify.
+func (r ReadOnlyMeta) Copy() Meta {
+	return copyMeta(r.m)
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler
+func (r ReadOnlyMeta) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return r.m.MarshalLogObject(enc)
+}
+
+func copyMeta(m Meta) Meta {
+	c := make(Meta, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,59 @@
+package logctx_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/logctx"
+)
+
+func TestReadOnlyMeta(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := logctx.WithMeta(context.Background(), map[string]string{"user_id": "southclaws", "deal_id": "xyz"})
+	view := logctx.Snapshot(ctx)
+
+	v, ok := view.Get("user_id")
+	a.True(ok)
+	a.Equal("southclaws", v)
+
+	_, ok = view.Get("missing")
+	a.False(ok)
+
+	a.Equal(2, view.Len())
+	a.Equal([]string{"deal_id", "user_id"}, view.Keys())
+
+	seen := map[string]string{}
+	view.Range(func(key, value string) bool {
+		seen[key] = value
+		return true
+	})
+	a.Equal(map[string]string{"user_id": "southclaws", "deal_id": "xyz"}, seen)
+}
+
+func TestReadOnlyMetaCopy(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := logctx.WithMeta(context.Background(), map[string]string{"user_id": "southclaws"})
+
+	// modifying a copy leaves both the view and the context alone
+	c := logctx.Snapshot(ctx).Copy()
+	c["user_id"] = "impostor"
+
+	v, _ := logctx.Snapshot(ctx).Get("user_id")
+	a.Equal("southclaws", v)
+}
+
+func TestReadOnlyMetaLog(t *testing.T) {
+	a := assert.New(t)
+	logger, buf := testLogger()
+
+	ctx := logctx.WithMeta(context.Background(), map[string]string{"user_id": "southclaws"})
+
+	logger.Info("test view", zap.Object("view", logctx.Snapshot(ctx)))
+
+	a.Contains(buf.String(), `"view":{"user_id":"southclaws"}`)
+}
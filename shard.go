@@ -0,0 +1,71 @@
+package logctx
+
+import (
+	"context"
+	"os"
+	"regexp"
+)
+
+// statefulSetOrdinal matches the ordinal suffix of a StatefulSet pod name, such
+// as "worker-3".
+var statefulSetOrdinal = regexp.MustCompile(`^.+-(\d+)$`)
+
+// ShardMeta returns metadata describing where this process sits in a
+// distributed batch job, read from the environment using common conventions.
+// Stamping this onto a root context lets logs from every worker be grouped by
+// shard when they're aggregated.
+//
+// The worker's index, and the total number of workers where it's known, are
+// written to "worker_index" and "worker_count" from the first of:
+//
+//   - Kubernetes Indexed Jobs: JOB_COMPLETION_INDEX
+//   - Cloud Run jobs: CLOUD_RUN_TASK_INDEX and CLOUD_RUN_TASK_COUNT
+//   - AWS Batch array jobs: AWS_BATCH_JOB_ARRAY_INDEX
+//   - Kubernetes StatefulSets: the ordinal at the end of HOSTNAME, such as the 3
+//     in "worker-3", when running on Kubernetes
+//
+// Spark executors on Kubernetes also get "spark_app_id" and "spark_executor_id"
+// from SPARK_APPLICATION_ID and SPARK_EXECUTOR_ID.
+//
+// The StatefulSet convention is only a guess based on the pod name, so pods of
+// other workloads whose generated names happen to end in digits will be picked
+// up too. If none of the conventions apply the returned Meta is empty.
+func ShardMeta() Meta {
+	meta := Meta{}
+
+	set := func(key, env string) bool {
+		if v := os.Getenv(env); v != "" {
+			meta[key] = v
+			return true
+		}
+		return false
+	}
+
+	switch {
+	case set("worker_index", "JOB_COMPLETION_INDEX"):
+	case set("worker_index", "CLOUD_RUN_TASK_INDEX"):
+		set("worker_count", "CLOUD_RUN_TASK_COUNT")
+	case set("worker_index", "AWS_BATCH_JOB_ARRAY_INDEX"):
+	case os.Getenv("KUBERNETES_SERVICE_HOST") != "":
+		if m := statefulSetOrdinal.FindStringSubmatch(os.Getenv("HOSTNAME")); m != nil {
+			meta["worker_index"] = m[1]
+		}
+	}
+
+	set("spark_app_id", "SPARK_APPLICATION_ID")
+	set("spark_executor_id", "SPARK_EXECUTOR_ID")
+
+	return meta
+}
+
+// WithShardMeta decorates the given context with `ShardMeta`. It's intended to
+// be called once on the root context when a worker starts, after which request
+// handlers can safely decorate contexts derived from it concurrently.
+func WithShardMeta(ctx context.Context) context.Context {
+	meta := ShardMeta()
+	if len(meta) == 0 {
+		return ctx
+	}
+
+	return WithMeta(ctx, meta)
+}
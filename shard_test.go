@@ -0,0 +1,93 @@
+package logctx_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/logctx"
+)
+
+// clearShardEnv makes sure the environment the tests run in doesn't leak in.
+func clearShardEnv(t *testing.T) {
+	for _, env := range []string{
+		"JOB_COMPLETION_INDEX",
+		"CLOUD_RUN_TASK_INDEX",
+		"CLOUD_RUN_TASK_COUNT",
+		"AWS_BATCH_JOB_ARRAY_INDEX",
+		"KUBERNETES_SERVICE_HOST",
+		"HOSTNAME",
+		"SPARK_APPLICATION_ID",
+		"SPARK_EXECUTOR_ID",
+	} {
+		t.Setenv(env, "")
+	}
+}
+
+func TestShardMetaIndexedJob(t *testing.T) {
+	a := assert.New(t)
+	clearShardEnv(t)
+
+	t.Setenv("JOB_COMPLETION_INDEX", "4")
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv("HOSTNAME", "job-4-xk2p9")
+
+	a.Equal(logctx.Meta{"worker_index": "4"}, logctx.ShardMeta())
+}
+
+func TestShardMetaCloudRun(t *testing.T) {
+	a := assert.New(t)
+	clearShardEnv(t)
+
+	t.Setenv("CLOUD_RUN_TASK_INDEX", "2")
+	t.Setenv("CLOUD_RUN_TASK_COUNT", "10")
+
+	a.Equal(logctx.Meta{"worker_index": "2", "worker_count": "10"}, logctx.ShardMeta())
+}
+
+func TestShardMetaAWSBatch(t *testing.T) {
+	a := assert.New(t)
+	clearShardEnv(t)
+
+	t.Setenv("AWS_BATCH_JOB_ARRAY_INDEX", "17")
+
+	a.Equal(logctx.Meta{"worker_index": "17"}, logctx.ShardMeta())
+}
+
+func TestShardMetaStatefulSet(t *testing.T) {
+	a := assert.New(t)
+	clearShardEnv(t)
+
+	t.Setenv("HOSTNAME", "worker-3")
+
+	// not on kubernetes, so the hostname is not trusted
+	a.Empty(logctx.ShardMeta())
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+
+	a.Equal(logctx.Meta{"worker_index": "3"}, logctx.ShardMeta())
+}
+
+func TestShardMetaSpark(t *testing.T) {
+	a := assert.New(t)
+	clearShardEnv(t)
+
+	t.Setenv("SPARK_APPLICATION_ID", "spark-abc123")
+	t.Setenv("SPARK_EXECUTOR_ID", "5")
+
+	a.Equal(logctx.Meta{"spark_app_id": "spark-abc123", "spark_executor_id": "5"}, logctx.ShardMeta())
+}
+
+func TestWithShardMeta(t *testing.T) {
+	a := assert.New(t)
+	clearShardEnv(t)
+
+	root := context.Background()
+	a.Equal(root, logctx.WithShardMeta(root))
+
+	t.Setenv("JOB_COMPLETION_INDEX", "1")
+
+	v, _ := logctx.Snapshot(logctx.WithShardMeta(root)).Get("worker_index")
+	a.Equal("1", v)
+}